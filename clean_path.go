@@ -0,0 +1,144 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// splitPath() разбивает URL-путь на элементы так же, как это делают
+// PathRouter.Add() и PathRouter.ServeHTTP(): начальный и конечный "/"
+// игнорируются, корневому пути соответствует пустой срез.
+func splitPath (path string) []string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if parts[0] == "" {
+		return []string {}
+	}
+
+	return parts
+}
+
+// pathMatches() сообщает, есть ли в дереве путей зарегистрированный
+// обработчик для разобранного пути. Используется для проверки кандидата
+// при редиректах (CleanPath/RedirectFixedPath) - в отличие от ServeHTTP(),
+// который ищет самый длинный путь разбора, не заходит "вглубь" дальше первого
+// найденного обработчика, поскольку для проверки существования маршрута
+// этого достаточно.
+func (pr *PathRouter) pathMatches (path []string) bool {
+	lastPartIndex := len(path) - 1
+
+	node := pr.pathTree
+	if node.GetHandler(lastPartIndex >= 0) != nil {
+		return true
+	}
+
+	for i, part := range path {
+		nextNode, _ := node.Match(part)
+		if nextNode == nil {
+			return false
+		}
+
+		node = nextNode
+		if node.GetHandler(i < lastPartIndex) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redirectTarget() пытается подобрать канонический URL-путь для редиректа:
+// если включен CleanPath или RedirectFixedPath - очищенный путь (см. CleanPath()).
+// Возвращает его, если в дереве путей для него нашелся обработчик.
+func (pr *PathRouter) redirectTarget (urlPath string) (location string, ok bool) {
+	if pr.CleanPath || pr.RedirectFixedPath {
+		if cleaned := CleanPath(urlPath); cleaned != urlPath && pr.pathMatches(splitPath(cleaned)) {
+			return cleaned, true
+		}
+	}
+
+	return "", false
+}
+
+// redirectStatusCode() возвращает код ответа для редиректа на канонический
+// URL: 301 для GET/HEAD (безопасно кешируется и повторяется как GET),
+// 308 для остальных методов (сохраняет метод и тело запроса при повторе).
+func redirectStatusCode (method string) int {
+	if method == http.MethodGet || method == http.MethodHead {
+		return http.StatusMovedPermanently
+	}
+
+	return http.StatusPermanentRedirect
+}
+
+// CleanPath() возвращает канонический вид URL-пути: схлопывает повторяющиеся
+// "/", разрешает "." и ".." (не выходя за пределы корня - лишние "..") и
+// обеспечивает начальный "/". Завершающий "/", если он был в исходном пути,
+// сохраняется. Если путь уже в каноническом виде, возвращается без
+// дополнительных аллокаций.
+func CleanPath (p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	if isCleanPath(p) {
+		return p
+	}
+
+	trailingSlash := len(p) > 1 && p[len(p) - 1] == '/'
+
+	segments := strings.Split(p, "/")
+	stack := make([]string, 0, len(segments))
+
+	for _, segment := range segments {
+		switch segment {
+			case "", ".":
+				// повторный "/" либо "." - пропускаем
+
+			case "..":
+				if len(stack) > 0 {
+					stack = stack[:len(stack) - 1]
+				}
+
+			default:
+				stack = append(stack, segment)
+		}
+	}
+
+	result := "/" + strings.Join(stack, "/")
+	if trailingSlash && result != "/" {
+		result += "/"
+	}
+
+	return result
+}
+
+// isCleanPath() сообщает, является ли путь уже каноническим: начинается с
+// "/" и не содержит "//" либо элементов "." или "..".
+func isCleanPath (p string) bool {
+	if p[0] != '/' {
+		return false
+	}
+
+	for i := 0; i < len(p); i++ {
+		if p[i] != '/' {
+			continue
+		}
+
+		j := i + 1
+		for j < len(p) && p[j] != '/' {
+			j++
+		}
+
+		switch p[i + 1:j] {
+			case "":
+				if j < len(p) {
+					return false
+				}
+
+			case ".", "..":
+				return false
+		}
+	}
+
+	return true
+}
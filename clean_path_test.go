@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCleanPath (t *testing.T) {
+	cases := []struct{in, out string} {
+		{"", "/"},
+		{"/", "/"},
+		{"/foo", "/foo"},
+		{"//foo", "/foo"},
+		{"/foo//bar", "/foo/bar"},
+		{"/foo/./bar", "/foo/bar"},
+		{"/foo/bar/..", "/foo"},
+		{"/foo/../../bar", "/bar"},
+		{"/foo/bar/", "/foo/bar/"},
+		{"foo/bar", "/foo/bar"},
+	}
+
+	for _, c := range cases {
+		if got := CleanPath(c.in); got != c.out {
+			t.Errorf("CleanPath(%q) = %q, expected %q", c.in, got, c.out)
+		}
+	}
+}
+
+func TestPathRouterRedirects (t *testing.T) {
+	pr := NewPathRouter(nil)
+	pr.RedirectFixedPath = true
+
+	pr.Add("foo/bar", http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	cases := []struct{method, url string; status int; location string} {
+		{http.MethodGet, "/foo/bar", http.StatusOK, ""},
+		{http.MethodGet, "/foo//bar", http.StatusMovedPermanently, "/foo/bar"},
+		{http.MethodPost, "/foo//bar", http.StatusPermanentRedirect, "/foo/bar"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.url, nil)
+		w := httptest.NewRecorder()
+		pr.ServeHTTP(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.url, c.status, w.Code)
+		}
+		if c.location != "" && w.Header().Get("Location") != c.location {
+			t.Errorf("%s: expected Location %q, got %q", c.url, c.location, w.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Handler - обработчик HTTP-запроса, используемый Router; совпадает с http.Handler.
+type Handler = http.Handler
+
+// Middleware - функция, оборачивающая Handler дополнительной логикой
+// (аутентификация, логирование и т. п.). Применяется в порядке регистрации:
+// middleware, добавленный первым через Use()/With(), вызывается первым.
+type Middleware func(Handler) Handler
+
+// routeMethods хранит обработчики узла дерева путей по HTTP-методу.
+// Для основных методов используются отдельные поля (без перебора по списку,
+// как в MethodRouter), редкие/нестандартные методы хранятся в anyOther.
+type routeMethods struct {
+	get, post, put, delete, patch, head, options http.Handler
+
+	// anyOther - обработчики для методов вне основного набора; nil, если не заданы.
+	anyOther map[string]http.Handler
+}
+
+// slot() возвращает указатель на поле, хранящее обработчик для одного из
+// основных методов, либо nil, если метод не входит в этот набор.
+func (rm *routeMethods) slot (method string) *http.Handler {
+	switch method {
+		case http.MethodGet:
+			return &rm.get
+		case http.MethodPost:
+			return &rm.post
+		case http.MethodPut:
+			return &rm.put
+		case http.MethodDelete:
+			return &rm.delete
+		case http.MethodPatch:
+			return &rm.patch
+		case http.MethodHead:
+			return &rm.head
+		case http.MethodOptions:
+			return &rm.options
+		default:
+			return nil
+	}
+}
+
+// Set() задает обработчик для метода.
+// Возвращает ошибку, если обработчик для этого метода уже задан.
+func (rm *routeMethods) Set (method string, handler http.Handler) error {
+	if slot := rm.slot(method); slot != nil {
+		if *slot != nil {
+			return &RouteSpecError {"handler already set for method " + method}
+		}
+
+		*slot = handler
+		return nil
+	}
+
+	if rm.anyOther == nil {
+		rm.anyOther = make(map[string]http.Handler)
+	} else if _, ok := rm.anyOther[method]; ok {
+		return &RouteSpecError {"handler already set for method " + method}
+	}
+
+	rm.anyOther[method] = handler
+	return nil
+}
+
+// Get() возвращает обработчик для метода; если обработчик для HEAD не задан,
+// возвращается обработчик GET (как в MethodRouter). Возвращает nil, если
+// подходящего обработчика нет.
+func (rm *routeMethods) Get (method string) http.Handler {
+	if slot := rm.slot(method); slot != nil {
+		if *slot != nil {
+			return *slot
+		}
+		if method == http.MethodHead {
+			return rm.get
+		}
+
+		return nil
+	}
+
+	return rm.anyOther[method]
+}
+
+// Allow() возвращает список зарегистрированных для узла методов в виде
+// строки, пригодной для заголовка Allow (методы разделены ", ").
+func (rm *routeMethods) Allow () string {
+	methods := make([]string, 0, 8)
+
+	if rm.get != nil {
+		methods = append(methods, http.MethodGet)
+	}
+	if rm.head != nil || rm.get != nil {
+		methods = append(methods, http.MethodHead)
+	}
+	if rm.post != nil {
+		methods = append(methods, http.MethodPost)
+	}
+	if rm.put != nil {
+		methods = append(methods, http.MethodPut)
+	}
+	if rm.delete != nil {
+		methods = append(methods, http.MethodDelete)
+	}
+	if rm.patch != nil {
+		methods = append(methods, http.MethodPatch)
+	}
+	if rm.options != nil {
+		methods = append(methods, http.MethodOptions)
+	}
+	for method := range rm.anyOther {
+		methods = append(methods, method)
+	}
+
+	return strings.Join(methods, ", ")
+}
+
+// Route - результат регистрации маршрута в Router.
+type Route struct {
+	method string
+	node   *pathPartNode
+
+	// router - роутер, в котором зарегистрирован маршрут; используется Name()
+	// для учета имени маршрута, см. route_name.go.
+	router *Router
+}
+
+// Router маршрутизирует запросы одновременно по URL-пути и HTTP-методу,
+// объединяя PathRouter и MethodRouter в одном дереве: каждый узел дерева
+// путей хранит свой собственный набор обработчиков по методам (routeMethods),
+// так что не требуется вкладывать один маршрутизатор в другой.
+//
+// Поддерживает глобальные middleware (Use) и middleware, применяемые только
+// к части маршрутов (With), по образцу echo/chi.
+type Router struct {
+	// pathTree - дерево заданных путей, см. PathRouter.pathTree.
+	pathTree *pathPartNode
+
+	// defaultHandler - обработчик, вызываемый, если путь не найден (не nil!).
+	defaultHandler http.Handler
+
+	// middleware - глобальные middleware, применяются ко всем маршрутам,
+	// зарегистрированным после их добавления (см. Use()).
+	middleware []Middleware
+
+	// MethodNotAllowedHandler - обработчик, вызываемый, если узел дерева
+	// путей найден, но обработчика для текущего HTTP-метода нет (не nil!).
+	// К моменту вызова заголовок Allow уже выставлен (см. ServeHTTP()).
+	// По умолчанию - обертка, которая лишь пишет код 405.
+	MethodNotAllowedHandler http.Handler
+
+	// HandleMethodNotAllowed - если true (по умолчанию), при найденном узле
+	// без обработчика для метода отвечает 405 через MethodNotAllowedHandler;
+	// если false, в этом случае (как и раньше) вызывается defaultHandler (404) -
+	// для обратной совместимости с версией без различения 404/405.
+	HandleMethodNotAllowed bool
+
+	// names - индекс именованных маршрутов (см. Route.Name()/Router.URL()),
+	// nil, если ни один маршрут не назван.
+	names map[string]*pathPartNode
+
+	// paramsPool - пул срезов Params для ServeHTTP(), см. growParamsPool().
+	paramsPool *paramsPool
+
+	// maxParamDepth - число элементов самого длинного из зарегистрированных
+	// на данный момент путей (без учета "хвоста"), по которому подобрана
+	// емкость срезов в paramsPool.
+	maxParamDepth int
+}
+
+// NewRouter() создает комбинированный маршрутизатор.
+// Если defaultHandler не задан, то используется обертка для net/http.NotFoundHandler().
+func NewRouter (defaultHandler http.Handler) *Router {
+	if defaultHandler == nil {
+		defaultHandler = http.NotFoundHandler()
+	}
+	return &Router {
+		pathTree:                 &pathPartNode {},
+		defaultHandler:           defaultHandler,
+		MethodNotAllowedHandler:  defaultMethodNotAllowedHandler,
+		HandleMethodNotAllowed:   true,
+		paramsPool:               newParamsPool(0),
+	}
+}
+
+// growParamsPool() расширяет (при необходимости) пул Params так, чтобы его
+// срезы вмещали параметры самого глубокого из зарегистрированных путей плюс
+// один слот на "хвост". Вызывается при регистрации маршрута, т. е. не на
+// горячем пути обработки запросов.
+func (r *Router) growParamsPool (depth int) {
+	if depth <= r.maxParamDepth {
+		return
+	}
+
+	r.maxParamDepth = depth
+	r.paramsPool = newParamsPool(depth + 1)
+}
+
+// Use() добавляет глобальные middleware, применяемые в порядке регистрации
+// ко всем маршрутам, зарегистрированным после этого вызова; уже
+// зарегистрированные маршруты ими не оборачиваются.
+func (r *Router) Use (mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+}
+
+// With() возвращает цепочку, накапливающую дополнительные middleware,
+// которые будут применены только к маршрутам, зарегистрированным через нее.
+func (r *Router) With (mw ...Middleware) *routeBinder {
+	return (&routeBinder {adder: r}).With(mw...)
+}
+
+// applyMiddleware() оборачивает handler заданными middleware в порядке
+// регистрации: первый в списке вызывается первым.
+func applyMiddleware (handler Handler, mw []Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		handler = mw[i](handler)
+	}
+
+	return handler
+}
+
+// Add() регистрирует handler для указанных метода и пути с учетом глобальных
+// middleware. Паникует, если путь задан некорректно либо обработчик для этой
+// пары метод+путь уже зарегистрирован - это ошибка в коде вызывающего,
+// обнаруживаемая при запуске, а не во время обработки запросов.
+func (r *Router) Add (method, pathString string, handler Handler) *Route {
+	return r.add(method, pathString, handler, nil)
+}
+
+func (r *Router) add (method, pathString string, handler Handler, mw []Middleware) *Route {
+	node, tailAllowed, e := r.pathTree.addPath(pathString)
+	if e != nil {
+		panic(e)
+	}
+
+	return registerAt(r, node, tailAllowed, method, handler, r.middleware, mw)
+}
+
+// registerAt() регистрирует handler для метода в уже найденном узле дерева
+// путей, оборачивая его сначала локальными (per-route/per-group) middleware,
+// затем глобальными middleware маршрутизатора.
+func registerAt (router *Router, node *pathPartNode, tailAllowed bool, method string, handler Handler, globalMw, localMw []Middleware) *Route {
+	handler = applyMiddleware(handler, localMw)
+	handler = applyMiddleware(handler, globalMw)
+
+	if node.methods == nil {
+		node.methods = &routeMethods {}
+	}
+	if e := node.methods.Set(method, handler); e != nil {
+		panic(e)
+	}
+	node.tailAllowed = node.tailAllowed || tailAllowed
+	router.growParamsPool(len(node.template))
+
+	return &Route {method: method, node: node, router: router}
+}
+
+// GET() регистрирует handler для метода GET и указанного пути.
+func (r *Router) GET (path string, handler Handler) *Route { return r.Add(http.MethodGet, path, handler) }
+
+// POST() регистрирует handler для метода POST и указанного пути.
+func (r *Router) POST (path string, handler Handler) *Route { return r.Add(http.MethodPost, path, handler) }
+
+// PUT() регистрирует handler для метода PUT и указанного пути.
+func (r *Router) PUT (path string, handler Handler) *Route { return r.Add(http.MethodPut, path, handler) }
+
+// DELETE() регистрирует handler для метода DELETE и указанного пути.
+func (r *Router) DELETE (path string, handler Handler) *Route { return r.Add(http.MethodDelete, path, handler) }
+
+// PATCH() регистрирует handler для метода PATCH и указанного пути.
+func (r *Router) PATCH (path string, handler Handler) *Route { return r.Add(http.MethodPatch, path, handler) }
+
+// HEAD() регистрирует handler для метода HEAD и указанного пути.
+func (r *Router) HEAD (path string, handler Handler) *Route { return r.Add(http.MethodHead, path, handler) }
+
+// OPTIONS() регистрирует handler для метода OPTIONS и указанного пути.
+func (r *Router) OPTIONS (path string, handler Handler) *Route { return r.Add(http.MethodOptions, path, handler) }
+
+// routeAdder - общий интерфейс Router и Group, позволяющий routeBinder
+// накапливать middleware одинаково для обоих уровней вложенности.
+type routeAdder interface {
+	add (method, path string, handler Handler, mw []Middleware) *Route
+}
+
+// routeBinder накапливает middleware для цепочек вида With(mw...).GET(path, h).
+type routeBinder struct {
+	adder      routeAdder
+	middleware []Middleware
+}
+
+// With() добавляет еще middleware поверх уже накопленных в этой цепочке.
+func (b *routeBinder) With (mw ...Middleware) *routeBinder {
+	return &routeBinder {adder: b.adder, middleware: append(append([]Middleware {}, b.middleware...), mw...)}
+}
+
+// Add() регистрирует handler для метода и пути с накопленными в цепочке middleware.
+func (b *routeBinder) Add (method, path string, handler Handler) *Route {
+	return b.adder.add(method, path, handler, b.middleware)
+}
+
+// GET() регистрирует handler для метода GET и указанного пути.
+func (b *routeBinder) GET (path string, handler Handler) *Route { return b.Add(http.MethodGet, path, handler) }
+
+// POST() регистрирует handler для метода POST и указанного пути.
+func (b *routeBinder) POST (path string, handler Handler) *Route { return b.Add(http.MethodPost, path, handler) }
+
+// PUT() регистрирует handler для метода PUT и указанного пути.
+func (b *routeBinder) PUT (path string, handler Handler) *Route { return b.Add(http.MethodPut, path, handler) }
+
+// DELETE() регистрирует handler для метода DELETE и указанного пути.
+func (b *routeBinder) DELETE (path string, handler Handler) *Route { return b.Add(http.MethodDelete, path, handler) }
+
+// PATCH() регистрирует handler для метода PATCH и указанного пути.
+func (b *routeBinder) PATCH (path string, handler Handler) *Route { return b.Add(http.MethodPatch, path, handler) }
+
+// HEAD() регистрирует handler для метода HEAD и указанного пути.
+func (b *routeBinder) HEAD (path string, handler Handler) *Route { return b.Add(http.MethodHead, path, handler) }
+
+// OPTIONS() регистрирует handler для метода OPTIONS и указанного пути.
+func (b *routeBinder) OPTIONS (path string, handler Handler) *Route { return b.Add(http.MethodOptions, path, handler) }
+
+// defaultMethodNotAllowedHandler - обработчик 405 по умолчанию: заголовок
+// Allow к этому моменту уже записан в ServeHTTP(), так что остается лишь
+// выставить код ответа.
+var defaultMethodNotAllowedHandler http.Handler = http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusMethodNotAllowed)
+})
+
+// ServeHTTP() разбирает путь запроса, находит соответствующий узел дерева
+// и вызывает обработчик, зарегистрированный для текущего HTTP-метода.
+// Если узел найден, но обработчика для метода нет, отвечает 405 с заголовком
+// Allow; если не найден ни один узел - вызывает defaultHandler (404).
+func (r *Router) ServeHTTP (w http.ResponseWriter, req *http.Request) {
+	path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if path[0] == "" {
+		path = []string {}
+	}
+	lastPartIndex := len(path) - 1
+
+	paramsPtr := r.paramsPool.get()
+	params := *paramsPtr
+
+	node := r.pathTree
+	var matchedNode *pathPartNode
+	matchedPos := 0
+	matchedParamCnt := 0
+
+	if node.methods != nil && (lastPartIndex < 0 || node.tailAllowed) {
+		matchedNode = node
+	}
+
+	for i, part := range path {
+		nextNode, paramName := node.Match(part)
+		if nextNode == nil {
+			break
+		}
+
+		if paramName != "" {
+			params = append(params, struct{ Key, Value string } {paramName, part})
+		}
+
+		node = nextNode
+		hasTail := i < lastPartIndex
+		if node.methods == nil || (hasTail && !node.tailAllowed) {
+			continue
+		}
+
+		matchedNode = node
+		matchedPos = i
+		matchedParamCnt = len(params)
+	}
+
+	if matchedNode == nil {
+		*paramsPtr = params[:0]
+		r.paramsPool.put(paramsPtr)
+		r.defaultHandler.ServeHTTP(w, req)
+		return
+	}
+
+	handler := matchedNode.methods.Get(req.Method)
+	if handler == nil {
+		*paramsPtr = params[:0]
+		r.paramsPool.put(paramsPtr)
+
+		if !r.HandleMethodNotAllowed {
+			r.defaultHandler.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Set("Allow", matchedNode.methods.Allow())
+		r.MethodNotAllowedHandler.ServeHTTP(w, req)
+		return
+	}
+
+	params = params[:matchedParamCnt]
+	if matchedPos < lastPartIndex {
+		params = append(params, struct{ Key, Value string } {"*", strings.Join(path[matchedPos + 1:], "/")})
+	}
+
+	if len(params) > 0 {
+		req = req.WithContext(&paramsContext {Context: req.Context(), params: params})
+	}
+
+	handler.ServeHTTP(w, req)
+
+	*paramsPtr = params[:0]
+	r.paramsPool.put(paramsPtr)
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterMethodDispatch (t *testing.T) {
+	r := NewRouter(nil)
+
+	r.GET("/user/#id", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("get"))
+	}))
+	r.POST("/user/#id", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("post"))
+	}))
+
+	cases := []struct{method, url, body string; status int} {
+		{http.MethodGet, "/user/1", "get", http.StatusOK},
+		{http.MethodPost, "/user/1", "post", http.StatusOK},
+		{http.MethodHead, "/user/1", "", http.StatusOK},
+		{http.MethodDelete, "/user/1", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/unknown", "", http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("%s %s: expected status %d, got %d", c.method, c.url, c.status, w.Code)
+		}
+		// httptest.ResponseRecorder, в отличие от настоящего net/http-сервера,
+		// не отбрасывает тело ответа для HEAD, так что для HEAD сверяем только статус.
+		if c.status == http.StatusOK && c.method != http.MethodHead && w.Body.String() != c.body {
+			t.Errorf("%s %s: expected body %q, got %q", c.method, c.url, c.body, w.Body.String())
+		}
+		if c.status == http.StatusMethodNotAllowed {
+			if allow := w.Header().Get("Allow"); allow == "" {
+				t.Errorf("%s %s: expected Allow header", c.method, c.url)
+			}
+		}
+	}
+}
+
+func TestRouterMethodNotAllowedToggle (t *testing.T) {
+	r := NewRouter(nil)
+	r.GET("/user/#id", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("get"))
+	}))
+	r.HandleMethodNotAllowed = false
+
+	req := httptest.NewRequest(http.MethodDelete, "/user/1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d with HandleMethodNotAllowed=false, got %d", http.StatusNotFound, w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "" {
+		t.Errorf("expected no Allow header with HandleMethodNotAllowed=false, got %q", allow)
+	}
+}
+
+func TestRouterCustomMethodNotAllowedHandler (t *testing.T) {
+	r := NewRouter(nil)
+	r.GET("/user/#id", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("get"))
+	}))
+	r.MethodNotAllowedHandler = http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("nope"))
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/user/1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected custom status %d, got %d", http.StatusTeapot, w.Code)
+	}
+	if w.Body.String() != "nope" {
+		t.Errorf("expected custom body %q, got %q", "nope", w.Body.String())
+	}
+	if allow := w.Header().Get("Allow"); allow == "" {
+		t.Errorf("expected Allow header to still be set for custom handler")
+	}
+}
+
+func TestRouterMiddleware (t *testing.T) {
+	r := NewRouter(nil)
+	var order []string
+
+	trace := func (name string) Middleware {
+		return func (next Handler) Handler {
+			return http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	r.Use(trace("global"))
+	r.With(trace("local")).GET("/ping", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		order = append(order, "handler")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := []string {"global", "local", "handler"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected call order %v, got %v", expected, order)
+	}
+	for i, name := range expected {
+		if order[i] != name {
+			t.Errorf("expected call order %v, got %v", expected, order)
+			break
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// joinPrefix() объединяет префикс группы с относительным путем, соблюдая
+// те же правила обрезки начального/конечного "/", что и parsePathSpec().
+func joinPrefix (prefix, path string) string {
+	prefix = strings.Trim(prefix, "/")
+	path = strings.Trim(path, "/")
+
+	switch {
+		case prefix == "":
+			return path
+		case path == "":
+			return prefix
+		default:
+			return prefix + "/" + path
+	}
+}
+
+// Group - группа маршрутов с общим префиксом пути и цепочкой middleware,
+// создается через Router.Group()/Group.Group(), по образцу echo/chi Group.
+//
+// Группа хранит узел дерева путей, соответствующий ее префиксу, поэтому
+// регистрация вложенных маршрутов начинается сразу с него, а не с корня
+// дерева.
+type Group struct {
+	// router - маршрутизатор, к которому относится группа.
+	router *Router
+
+	// prefix - полный префикс группы (с учетом родительских групп), без
+	// начального/конечного "/"; используется для построения префикса
+	// вложенных групп. Name()/URL() берут шаблон пути не отсюда, а из
+	// pathPartNode.template.
+	prefix string
+
+	// node - узел дерева путей, соответствующий prefix.
+	node *pathPartNode
+
+	// middleware - middleware группы (и унаследованные от родительской группы),
+	// применяются ко всем маршрутам группы перед глобальными middleware роутера.
+	middleware []Middleware
+}
+
+// Group() создает группу маршрутов с общим префиксом и middleware.
+// Паникует, если префикс задан некорректно либо заканчивается "*"
+// (группа задает только префикс, а не конечную точку разбора).
+func (r *Router) Group (prefix string, mw ...Middleware) *Group {
+	return newGroup(r, r.pathTree, "", prefix, mw)
+}
+
+// Use() добавляет middleware, применяемые ко всем маршрутам группы,
+// зарегистрированным после этого вызова.
+func (g *Group) Use (mw ...Middleware) {
+	g.middleware = append(g.middleware, mw...)
+}
+
+// With() возвращает цепочку, накапливающую дополнительные middleware,
+// которые будут применены только к маршрутам, зарегистрированным через нее.
+func (g *Group) With (mw ...Middleware) *routeBinder {
+	return (&routeBinder {adder: g}).With(mw...)
+}
+
+// Group() создает вложенную группу с префиксом, дополняющим префикс
+// родительской группы, наследующую ее middleware.
+func (g *Group) Group (prefix string, mw ...Middleware) *Group {
+	return newGroup(g.router, g.node, g.prefix, prefix, append(append([]Middleware {}, g.middleware...), mw...))
+}
+
+// newGroup() резолвит узел дерева путей для prefix (относительно baseNode)
+// и создает группу с накопленными middleware.
+func newGroup (router *Router, baseNode *pathPartNode, basePrefix, prefix string, mw []Middleware) *Group {
+	node, tailAllowed, e := baseNode.addPath(prefix)
+	if e != nil {
+		panic(e)
+	}
+	if tailAllowed {
+		panic(&RouteSpecError {"group prefix cannot end with \"*\""})
+	}
+
+	return &Group {router: router, prefix: joinPrefix(basePrefix, prefix), node: node, middleware: mw}
+}
+
+// Add() регистрирует handler для метода и пути относительно префикса группы.
+func (g *Group) Add (method, path string, handler Handler) *Route {
+	return g.add(method, path, handler, nil)
+}
+
+func (g *Group) add (method, pathString string, handler Handler, mw []Middleware) *Route {
+	node, tailAllowed, e := g.node.addPath(pathString)
+	if e != nil {
+		panic(e)
+	}
+
+	localMw := append(append([]Middleware {}, g.middleware...), mw...)
+	return registerAt(g.router, node, tailAllowed, method, handler, g.router.middleware, localMw)
+}
+
+// GET() регистрирует handler для метода GET и указанного пути внутри группы.
+func (g *Group) GET (path string, handler Handler) *Route { return g.Add(http.MethodGet, path, handler) }
+
+// POST() регистрирует handler для метода POST и указанного пути внутри группы.
+func (g *Group) POST (path string, handler Handler) *Route { return g.Add(http.MethodPost, path, handler) }
+
+// PUT() регистрирует handler для метода PUT и указанного пути внутри группы.
+func (g *Group) PUT (path string, handler Handler) *Route { return g.Add(http.MethodPut, path, handler) }
+
+// DELETE() регистрирует handler для метода DELETE и указанного пути внутри группы.
+func (g *Group) DELETE (path string, handler Handler) *Route { return g.Add(http.MethodDelete, path, handler) }
+
+// PATCH() регистрирует handler для метода PATCH и указанного пути внутри группы.
+func (g *Group) PATCH (path string, handler Handler) *Route { return g.Add(http.MethodPatch, path, handler) }
+
+// HEAD() регистрирует handler для метода HEAD и указанного пути внутри группы.
+func (g *Group) HEAD (path string, handler Handler) *Route { return g.Add(http.MethodHead, path, handler) }
+
+// OPTIONS() регистрирует handler для метода OPTIONS и указанного пути внутри группы.
+func (g *Group) OPTIONS (path string, handler Handler) *Route { return g.Add(http.MethodOptions, path, handler) }
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGroupRouting (t *testing.T) {
+	r := NewRouter(nil)
+	var order []string
+
+	trace := func (name string) Middleware {
+		return func (next Handler) Handler {
+			return http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	api := r.Group("/api", trace("api"))
+	users := api.Group("/users", trace("users"))
+	users.GET("/#id", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		order = append(order, "handler")
+		w.Write([]byte("user"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/42", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "user" {
+		t.Fatalf("expected 200 \"user\", got %d %q", w.Code, w.Body.String())
+	}
+
+	expected := []string {"api", "users", "handler"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected call order %v, got %v", expected, order)
+	}
+	for i, name := range expected {
+		if order[i] != name {
+			t.Errorf("expected call order %v, got %v", expected, order)
+			break
+		}
+	}
+}
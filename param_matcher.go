@@ -0,0 +1,230 @@
+package router
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// paramMatcher проверяет, подходит ли значение элемента пути под параметр,
+// и возвращает (при необходимости нормализованное) значение параметра.
+// Используется для задания типа параметра через "{name:type}" - см. AddPart()
+// и RegisterParamType().
+type paramMatcher interface {
+	Match (value string) (string, bool)
+}
+
+// intMatcherT - встроенный тип параметра "int": положительное целое число.
+// Используется и для исходного синтаксиса "#name", и для "{name:int}".
+type intMatcherT struct{}
+
+func (intMatcherT) Match (value string) (string, bool) {
+	i, e := strconv.Atoi(value)
+	if e != nil || i <= 0 {
+		return "", false
+	}
+
+	return value, true
+}
+
+// stringMatcherT - встроенный тип параметра "string": сопоставляется с любым
+// элементом пути, в том числе пустым (например, между "//" в URL).
+// Используется для "$name", ":name" и "{name}".
+type stringMatcherT struct{}
+
+func (stringMatcherT) Match (value string) (string, bool) {
+	return value, true
+}
+
+// uuidPattern - канонический формат UUID (8-4-4-4-12 шестнадцатеричных цифр).
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// uuidMatcherT - встроенный тип параметра "uuid".
+type uuidMatcherT struct{}
+
+func (uuidMatcherT) Match (value string) (string, bool) {
+	if !uuidPattern.MatchString(value) {
+		return "", false
+	}
+
+	return value, true
+}
+
+// regexMatcher оборачивает скомпилированное регулярное выражение, заданное
+// непосредственно в спецификации пути ("{name:[a-z0-9-]+}"). Выражение
+// компилируется один раз при регистрации маршрута и кешируется в узле дерева
+// путей (pathParamNode.Matcher), так что сопоставление на каждый запрос -
+// это один вызов FindStringSubmatch().
+type regexMatcher struct {
+	re *regexp.Regexp
+}
+
+// newRegexMatcher() компилирует pattern, требуя полного совпадения со значением.
+func newRegexMatcher (pattern string) (*regexMatcher, error) {
+	re, e := regexp.Compile("^(?:" + pattern + ")$")
+	if e != nil {
+		return nil, &RouteSpecError {"invalid parameter pattern \"" + pattern + "\": " + e.Error()}
+	}
+
+	return &regexMatcher {re: re}, nil
+}
+
+func (m *regexMatcher) Match (value string) (string, bool) {
+	if m.re.FindStringSubmatch(value) == nil {
+		return "", false
+	}
+
+	return value, true
+}
+
+var (
+	intMatcherInst    paramMatcher = intMatcherT {}
+	stringMatcherInst paramMatcher = stringMatcherT {}
+	uuidMatcherInst   paramMatcher = uuidMatcherT {}
+)
+
+// paramTypeRegistry - именованные типы параметров, доступные в спецификации
+// пути как "{name:type}". Пополняется через RegisterParamType().
+var paramTypeRegistry = map[string]paramMatcher {
+	"int":    intMatcherInst,
+	"uuid":   uuidMatcherInst,
+	"string": stringMatcherInst,
+}
+
+// paramTypePriority - приоритет (см. paramIntType и соседние константы) для
+// именованных типов; нестандартные (зарегистрированные через
+// RegisterParamType(), либо заданные как inline-regex) получают приоритет regex-типа.
+var paramTypePriority = map[string]int {
+	"int":    paramIntType,
+	"uuid":   paramUUIDType,
+	"string": paramStringType,
+}
+
+// RegisterParamType() регистрирует именованный матчер параметров, доступный
+// в спецификации пути как "{name:typeName}".
+// Паникует, если тип с таким именем уже зарегистрирован.
+func RegisterParamType (typeName string, matcher paramMatcher) {
+	if _, ok := paramTypeRegistry[typeName]; ok {
+		panic(&RouteSpecError {"param type \"" + typeName + "\" already registered"})
+	}
+
+	paramTypeRegistry[typeName] = matcher
+	paramTypePriority[typeName] = paramRegexType
+}
+
+// matchersEqual() сообщает, задают ли два матчера одно и то же правило.
+// regexMatcher компилируется заново при каждом разборе спецификации пути,
+// так что для него сравниваются исходные шаблоны regex; остальные типы -
+// встроенные синглтоны и зарегистрированные через RegisterParamType() -
+// сравниваются напрямую.
+func matchersEqual (a, b paramMatcher) bool {
+	ra, aIsRegex := a.(*regexMatcher)
+	rb, bIsRegex := b.(*regexMatcher)
+	if aIsRegex || bIsRegex {
+		return aIsRegex && bIsRegex && ra.re.String() == rb.re.String()
+	}
+
+	return a == b
+}
+
+// isTailSpec() определяет, задает ли элемент пути необязательный "хвост":
+// это либо "*", либо эквивалентный ему "{name:*}". Имя в "{name:*}" чисто
+// косметическое и нигде не сохраняется - захваченный "хвост" всегда
+// записывается в параметр "*" (см. PathRouter.ServeHTTP() и Router.URL()),
+// так что "{rest:*}" и "*" с точки зрения разбора пути неотличимы.
+func isTailSpec (part string) bool {
+	if part[0] == pathOptTail {
+		return true
+	}
+
+	if part[0] != pathBraceOpen || part[len(part) - 1] != '}' {
+		return false
+	}
+
+	i := strings.IndexByte(part, ':')
+	return i >= 0 && part[i + 1:len(part) - 1] == "*"
+}
+
+// pathPartDescriptor - разобранное (но еще не добавленное в дерево) описание
+// одного элемента спецификации пути: либо точное значение (isParam = false,
+// Literal задано), либо параметр (isParam = true, заданы Name/Type/Matcher).
+// Используется и AddPart() для построения дерева путей, и при сохранении
+// шаблона пути именованного маршрута (см. route_name.go) - так обе стороны
+// разбирают элемент пути одинаково, не дублируя правила синтаксиса.
+type pathPartDescriptor struct {
+	Literal string
+	Name    string
+	Type    int
+	Matcher paramMatcher
+	isParam bool
+}
+
+// parsePathPart() разбирает один элемент спецификации пути (точное значение
+// либо параметр в одном из поддерживаемых синтаксисов - "$name", "#name",
+// ":name", "{name}"/"{name:type}"), не изменяя дерево путей.
+func parsePathPart (part string) (pathPartDescriptor, error) {
+	if part == "" {
+		return pathPartDescriptor{}, &RouteSpecError {"empty path component"}
+	}
+
+	firstChar := part[0]
+	switch firstChar {
+		case pathOptTail:
+			return pathPartDescriptor{}, &RouteSpecError {"incorrect path component"}
+
+		case pathParamPrefix, pathIndexPrefix, pathColonPrefix:
+			t := paramStringType
+			m := stringMatcherInst
+			if firstChar == pathIndexPrefix {
+				t = paramIntType
+				m = intMatcherInst
+			}
+
+			name := part[1:]
+			if name == "" {
+				return pathPartDescriptor{}, &RouteSpecError {"empty parameter name"}
+			}
+
+			return pathPartDescriptor {Name: name, Type: t, Matcher: m, isParam: true}, nil
+
+		case pathBraceOpen:
+			return parseBraceParam(part)
+
+		default:
+			return pathPartDescriptor {Literal: part}, nil
+	}
+}
+
+// parseBraceParam() разбирает компонент пути вида "{name}" или "{name:type}"
+// (где type - имя зарегистрированного типа либо inline-regex).
+func parseBraceParam (part string) (pathPartDescriptor, error) {
+	if part[len(part) - 1] != '}' {
+		return pathPartDescriptor{}, &RouteSpecError {"unterminated \"{\" in path component"}
+	}
+
+	body := part[1:len(part) - 1]
+	name := body
+	typeName := ""
+	if i := strings.IndexByte(body, ':'); i >= 0 {
+		name = body[:i]
+		typeName = body[i + 1:]
+	}
+	if name == "" {
+		return pathPartDescriptor{}, &RouteSpecError {"empty parameter name"}
+	}
+
+	if typeName == "" {
+		return pathPartDescriptor {Name: name, Type: paramStringType, Matcher: stringMatcherInst, isParam: true}, nil
+	}
+
+	if matcher, ok := paramTypeRegistry[typeName]; ok {
+		return pathPartDescriptor {Name: name, Type: paramTypePriority[typeName], Matcher: matcher, isParam: true}, nil
+	}
+
+	matcher, e := newRegexMatcher(typeName)
+	if e != nil {
+		return pathPartDescriptor{}, e
+	}
+
+	return pathPartDescriptor {Name: name, Type: paramRegexType, Matcher: matcher, isParam: true}, nil
+}
@@ -0,0 +1,101 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestColonAndBraceParamSyntax (t *testing.T) {
+	pr := NewPathRouter(nil)
+
+	mustAdd := func (path string) {
+		if e := pr.Add(path, http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(path))
+		})); e != nil {
+			t.Fatalf("Add(%q): %s", path, e.Error())
+		}
+	}
+
+	mustAdd("users/:name")
+	mustAdd("items/{id:int}")
+	mustAdd("items/{slug:[a-z0-9-]+}")
+	mustAdd("sessions/{token:uuid}")
+	mustAdd("files/{rest:*}")
+
+	cases := []struct{url, expected string} {
+		{"/users/joe", "users/:name"},
+		{"/items/42", "items/{id:int}"},
+		{"/items/my-slug-1", "items/{slug:[a-z0-9-]+}"},
+		{"/sessions/123e4567-e89b-12d3-a456-426614174000", "sessions/{token:uuid}"},
+		{"/files/a/b/c", "files/{rest:*}"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.url, nil)
+		w := httptest.NewRecorder()
+		pr.ServeHTTP(w, req)
+
+		if w.Body.String() != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.url, c.expected, w.Body.String())
+		}
+	}
+}
+
+func TestInvalidParamConstraints (t *testing.T) {
+	pr := NewPathRouter(nil)
+
+	if e := pr.Add("items/{}", http.NotFoundHandler()); e == nil {
+		t.Error("expected error for empty parameter name")
+	}
+	if e := pr.Add("items/{bad", http.NotFoundHandler()); e == nil {
+		t.Error("expected error for unterminated \"{\"")
+	}
+	if e := pr.Add("items/{slug:[}", http.NotFoundHandler()); e == nil {
+		t.Error("expected error for invalid regex pattern")
+	}
+}
+
+func TestRegisterParamType (t *testing.T) {
+	RegisterParamType("evenDigit", paramMatcherFunc(func (value string) (string, bool) {
+		return value, len(value) == 1 && value[0] >= '0' && value[0] <= '9' && (value[0] - '0') % 2 == 0
+	}))
+
+	pr := NewPathRouter(nil)
+	if e := pr.Add("n/{d:evenDigit}", http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("even"))
+	})); e != nil {
+		t.Fatal(e.Error())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/n/4", nil)
+	w := httptest.NewRecorder()
+	pr.ServeHTTP(w, req)
+	if w.Body.String() != "even" {
+		t.Errorf("expected \"even\", got %q", w.Body.String())
+	}
+}
+
+func TestConflictingParamMatcher (t *testing.T) {
+	pr := NewPathRouter(nil)
+
+	if e := pr.Add("items/{slug:[a-z]+}", http.NotFoundHandler()); e != nil {
+		t.Fatal(e.Error())
+	}
+
+	// тот же паттерн под тем же именем на соседнем пути - не конфликт,
+	// т. к. regexMatcher сравнивается по исходному шаблону (см. matchersEqual()).
+	if e := pr.Add("items/{slug:[a-z]+}/edit", http.NotFoundHandler()); e != nil {
+		t.Errorf("same pattern reused: expected no error, got %q", e.Error())
+	}
+
+	// другой паттерн под тем же именем на том же уровне дерева - конфликт.
+	if e := pr.Add("items/{slug:[0-9]+}", http.NotFoundHandler()); e == nil {
+		t.Error("expected error for conflicting matcher with the same parameter name")
+	}
+}
+
+// paramMatcherFunc позволяет задавать paramMatcher функцией в тестах.
+type paramMatcherFunc func (string) (string, bool)
+
+func (f paramMatcherFunc) Match (value string) (string, bool) { return f(value) }
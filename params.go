@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"sync"
+)
+
+// Params - параметры, извлеченные при разборе URL-пути запроса, в порядке их
+// появления в пути. Заменяет N отдельных context.WithValue() (по одному на
+// параметр, каждый аллоцирующий новый узел контекста) единственным вызовом -
+// см. PathRouter.ServeHTTP()/Router.ServeHTTP() и paramsContext.
+type Params []struct{ Key, Value string }
+
+// Get() возвращает значение параметра по имени либо "", если параметр
+// с таким именем не был сопоставлен.
+func (p Params) Get (name string) string {
+	value, _ := p.find(name)
+	return value
+}
+
+// ByIndex() возвращает значение параметра по его позиции в срезе (в порядке
+// появления в пути, см. Params) либо "", если индекс вне диапазона.
+func (p Params) ByIndex (i int) string {
+	if i < 0 || i >= len(p) {
+		return ""
+	}
+
+	return p[i].Value
+}
+
+// find() - общая реализация поиска по имени для Get() и paramsContext.Value().
+func (p Params) find (name string) (value string, ok bool) {
+	for _, entry := range p {
+		if entry.Key == name {
+			return entry.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// paramsKey - ключ контекста, под которым сохраняется Params, см. ParamsFromContext().
+type paramsKey struct{}
+
+// ParamsFromContext() возвращает Params, сохраненные в контексте запроса
+// маршрутизатором (PathRouter/Router), либо nil, если параметров нет.
+func ParamsFromContext (ctx context.Context) Params {
+	params, _ := ctx.Value(paramsKey {}).(Params)
+	return params
+}
+
+// paramsContext оборачивает контекст запроса для доступа к Params без
+// дополнительных аллокаций context.WithValue(): единственный WithValue()
+// в цепочке - это сам paramsContext (создаваемый один раз на запрос), а
+// Value() отвечает на paramsKey{} и, для обратной совместимости со старым
+// API (context.Value("uid") и т. п.), на запрос по имени параметра напрямую,
+// прежде чем обратиться к родительскому контексту.
+type paramsContext struct {
+	context.Context
+	params Params
+}
+
+func (c *paramsContext) Value (key any) any {
+	if _, ok := key.(paramsKey); ok {
+		return c.params
+	}
+
+	if name, ok := key.(string); ok {
+		if value, found := c.params.find(name); found {
+			return value
+		}
+	}
+
+	return c.Context.Value(key)
+}
+
+// paramsPool - пул срезов Params, используется, чтобы не аллоцировать срез
+// параметров на каждый запрос (см. httprouter). Емкость срезов из пула
+// подбирается по самому глубокому из зарегистрированных на момент создания
+// пула путей - см. PathRouter.growParamsPool()/Router.growParamsPool().
+type paramsPool struct {
+	pool sync.Pool
+}
+
+// newParamsPool() создает пул, выдающий срезы Params заданной емкости.
+func newParamsPool (capacity int) *paramsPool {
+	return &paramsPool {
+		pool: sync.Pool {
+			New: func () any {
+				params := make(Params, 0, capacity)
+				return &params
+			},
+		},
+	}
+}
+
+// get() возвращает срез Params из пула (длины 0, но, как правило, с уже
+// выделенной вместимостью).
+func (pp *paramsPool) get () *Params {
+	return pp.pool.Get().(*Params)
+}
+
+// put() возвращает срез Params в пул, обнулив его длину.
+func (pp *paramsPool) put (params *Params) {
+	*params = (*params)[:0]
+	pp.pool.Put(params)
+}
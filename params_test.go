@@ -0,0 +1,46 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParamsFromContextAndLegacyLookup (t *testing.T) {
+	r := NewRouter(nil)
+	r.GET("/user/#id/{rest:*}", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		params := ParamsFromContext(req.Context())
+		if params.Get("id") != "42" {
+			t.Errorf("Params.Get(\"id\"): expected \"42\", got %q", params.Get("id"))
+		}
+		if params.ByIndex(0) != "42" {
+			t.Errorf("Params.ByIndex(0): expected \"42\", got %q", params.ByIndex(0))
+		}
+		if v := req.Context().Value("id"); v != "42" {
+			t.Errorf("legacy context.Value(\"id\"): expected \"42\", got %v", v)
+		}
+		if v := req.Context().Value("*"); v != "a/b" {
+			t.Errorf("legacy context.Value(\"*\"): expected \"a/b\", got %v", v)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user/42/a/b", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestParamsPoolReuse (t *testing.T) {
+	r := NewRouter(nil)
+	r.GET("/item/#id", http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(ParamsFromContext(req.Context()).Get("id")))
+	}))
+
+	for i, id := range []string {"1", "2", "3"} {
+		req := httptest.NewRequest(http.MethodGet, "/item/" + id, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Body.String() != id {
+			t.Errorf("request %d: expected body %q, got %q", i, id, w.Body.String())
+		}
+	}
+}
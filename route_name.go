@@ -0,0 +1,107 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name() присваивает маршруту имя, используемое для генерации URL через
+// Router.URL(). Паникует, если имя уже занято другим маршрутом - это ошибка
+// в коде вызывающего, обнаруживаемая при запуске, а не во время обработки запросов.
+func (rt *Route) Name (name string) *Route {
+	if e := rt.router.registerName(name, rt.node); e != nil {
+		panic(e)
+	}
+
+	return rt
+}
+
+// registerName() добавляет запись в индекс именованных маршрутов.
+// Возвращает ошибку, если имя уже занято.
+func (r *Router) registerName (name string, node *pathPartNode) error {
+	if r.names == nil {
+		r.names = make(map[string]*pathPartNode)
+	} else if _, ok := r.names[name]; ok {
+		return &RouteSpecError {"route name \"" + name + "\" is already registered"}
+	}
+
+	r.names[name] = node
+	return nil
+}
+
+// URL() восстанавливает URL-путь именованного маршрута (см. Route.Name()),
+// подставляя в его шаблон значения параметров.
+//
+// params может быть либо единственным значением map[string]string (подстановка
+// по имени параметра), либо списком значений любого типа (подставляются
+// позиционно, в порядке следования параметров в шаблоне пути; каждое значение
+// приводится к строке через fmt.Sprint). Значение "хвоста" ("*") при позиционной
+// подстановке, если он есть в шаблоне, указывается последним аргументом.
+// "Хвост" в шаблоне всегда ищется под ключом "*", даже если он был задан как
+// "{name:*}" - собственное имя в этом синтаксисе не сохраняется (см. isTailSpec()).
+//
+// Возвращает ошибку, если маршрут с таким именем не зарегистрирован, для
+// параметра не нашлось значения, либо значение не удовлетворяет типу/regex
+// параметра (например, нечисловое значение для "#id"/"{id:int}").
+func (r *Router) URL (name string, params ...any) (string, error) {
+	node, ok := r.names[name]
+	if !ok {
+		return "", &RouteSpecError {"route name \"" + name + "\" is not registered"}
+	}
+
+	named, positional := splitURLParams(params)
+
+	segments := make([]string, 0, len(node.template) + 1)
+	posIdx := 0
+
+	for _, part := range node.template {
+		if !part.isParam {
+			segments = append(segments, part.Literal)
+			continue
+		}
+
+		value, ok := named[part.Name]
+		if !ok {
+			if posIdx >= len(positional) {
+				return "", &RouteSpecError {"missing value for parameter \"" + part.Name + "\""}
+			}
+
+			value = positional[posIdx]
+			posIdx++
+		}
+
+		if _, ok := part.Matcher.Match(value); !ok {
+			return "", &RouteSpecError {"value \"" + value + "\" does not satisfy parameter \"" + part.Name + "\""}
+		}
+
+		segments = append(segments, value)
+	}
+
+	if node.tailAllowed {
+		if tail, ok := named["*"]; ok {
+			segments = append(segments, tail)
+		} else if posIdx < len(positional) {
+			segments = append(segments, positional[posIdx])
+		}
+	}
+
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// splitURLParams() распознает аргументы URL(): единственный map[string]string
+// трактуется как именованные значения, иначе каждый аргумент - позиционное
+// значение, приведенное к строке.
+func splitURLParams (params []any) (named map[string]string, positional []string) {
+	if len(params) == 1 {
+		if m, ok := params[0].(map[string]string); ok {
+			return m, nil
+		}
+	}
+
+	positional = make([]string, len(params))
+	for i, p := range params {
+		positional[i] = fmt.Sprint(p)
+	}
+
+	return nil, positional
+}
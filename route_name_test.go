@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRouteURLPositionalAndNamed (t *testing.T) {
+	r := NewRouter(nil)
+	r.GET("/user/#id/avatar", http.NotFoundHandler()).Name("user.avatar")
+	r.GET("/files/{rest:*}", http.NotFoundHandler()).Name("files")
+
+	url, e := r.URL("user.avatar", 42)
+	if e != nil {
+		t.Fatalf("URL() positional: %s", e.Error())
+	}
+	if url != "/user/42/avatar" {
+		t.Errorf("expected \"/user/42/avatar\", got %q", url)
+	}
+
+	url, e = r.URL("user.avatar", map[string]string {"id": "7"})
+	if e != nil {
+		t.Fatalf("URL() named: %s", e.Error())
+	}
+	if url != "/user/7/avatar" {
+		t.Errorf("expected \"/user/7/avatar\", got %q", url)
+	}
+
+	url, e = r.URL("files", "a/b")
+	if e != nil {
+		t.Fatalf("URL() tail: %s", e.Error())
+	}
+	if url != "/files/a/b" {
+		t.Errorf("expected \"/files/a/b\", got %q", url)
+	}
+
+	if _, e := r.URL("user.avatar", "not-an-int"); e == nil {
+		t.Error("expected error for value not satisfying \"#id\"")
+	}
+
+	if _, e := r.URL("unknown.route"); e == nil {
+		t.Error("expected error for unregistered route name")
+	}
+}
+
+func TestRouteNameAlreadyRegistered (t *testing.T) {
+	defer func () {
+		if recover() == nil {
+			t.Error("expected panic for duplicate route name")
+		}
+	}()
+
+	r := NewRouter(nil)
+	r.GET("/a", http.NotFoundHandler()).Name("dup")
+	r.GET("/b", http.NotFoundHandler()).Name("dup")
+}
@@ -3,10 +3,8 @@
 package router
 
 import (
-	"context"
 	"net/http"
 	"strings"
-	"strconv"
 )
 
 // RouteSpecError - тип ошибки для некорректно заданного маршрута.
@@ -103,12 +101,18 @@ const (
 	pathOptTail     = '*' // необязательный "хвост" пути
 	pathParamPrefix = '$' // префикс имени строкового параметра
 	pathIndexPrefix = '#' // префикс имени положителного целочисленного параметра
+	pathColonPrefix = ':' // альтернативный префикс строкового параметра (в стиле chi/httprouter)
+	pathBraceOpen   = '{' // открывающая скобка параметра в стиле echo/chi, возможно с типом/regex через ":"
 )
 
 // Типы/приоритеты (меньше - выше) параметров пути.
+// Чем специфичнее (меньше значений способен сопоставить) тип параметра,
+// тем выше его приоритет - он проверяется раньше.
 const (
-	paramIndexType  = 2 // целочисленный
-	paramStringType = 3 // строковый
+	paramIntType    = 2 // целочисленный (#, {name:int})
+	paramUUIDType   = 3 // UUID ({name:uuid})
+	paramRegexType  = 4 // regex или пользовательский тип, см. RegisterParamType() ({name:pattern})
+	paramStringType = 5 // строковый ($, :name, {name})
 )
 
 // pathPartNode - узел дерева элементов заданных путей.
@@ -127,9 +131,21 @@ type pathPartNode struct {
 	// nil, если узел не может быть конечной точкой в разборе пути.
 	handler http.Handler
 
+	// methods - обработчики для данного узла, зарегистрированные по HTTP-методу
+	// (используется Router); nil, если узел не зарегистрирован как точка
+	// диспетчеризации по методу.
+	methods *routeMethods
+
 	// tailAllowed - true, если узел может быть конечной точкой разбора,
 	// и путь может содержать "хвост"; иначе false.
 	tailAllowed bool
+
+	// template - шаблон пути от корня дерева до данного узла (элементы в том
+	// виде, в котором они были заданы при регистрации), используется для
+	// генерации URL именованных маршрутов (см. route_name.go).
+	// nil для узлов, которые сами по себе не являются ни концом зарегистрированного
+	// пути, ни префиксом группы (см. Router.Group()).
+	template []pathPartDescriptor
 }
 
 // pathParamNode - элемент списка возможных параметров для определенного элемента пути.
@@ -145,6 +161,10 @@ type pathParamNode struct {
 
 	// Type - тип/приоритет параметра.
 	Type int
+
+	// Matcher - проверяет и (при необходимости) нормализует значение элемента
+	// пути для данного параметра, см. paramMatcher.
+	Matcher paramMatcher
 }
 
 // pathIndexNode - узел дерева поиска для точных значений элемента пути.
@@ -163,34 +183,20 @@ type pathIndexNode struct {
 }
 
 // AddPart() добавляет (при необходимости) в дерево путей и возвращает следующий элемент (точное значение или параметр).
+// Помимо точных значений распознает "$name"/"#name" (исходный синтаксис),
+// ":name" и "{name}"/"{name:type}" (echo/chi-подобный синтаксис, см. param_matcher.go).
 // Возвращает ошибку, если в списке уже есть параметр с указанным типом, но с другим именем.
 func (pn *pathPartNode) AddPart (part string) (*pathPartNode, error) {
-	if part == "" {
-		return nil, &RouteSpecError {"empty path component"}
+	d, e := parsePathPart(part)
+	if e != nil {
+		return nil, e
 	}
 
-	firstChar := part[0]
-	switch firstChar {
-		case pathOptTail:
-			return nil, &RouteSpecError {"incorrect path component"}
-
-		case pathParamPrefix, pathIndexPrefix:
-			var t int
-			if firstChar == pathIndexPrefix {
-				t = paramIndexType
-			} else {
-				t = paramStringType
-			}
-			name := part[1:]
-			if name == "" {
-				return nil, &RouteSpecError {"empty parameter name"}
-			}
-
-			return pn.addParam(name, t)
-
-		default:
-			return pn.addLiteral(part), nil
+	if !d.isParam {
+		return pn.addLiteral(d.Literal), nil
 	}
+
+	return pn.addParam(d.Name, d.Type, d.Matcher)
 }
 
 // addLiteral() добавляет в дерево путей и возвращает следующий узел для точного значения элемента пути.
@@ -205,11 +211,12 @@ func (pn *pathPartNode) addLiteral (key string) *pathPartNode {
 }
 
 // addParam() добавляет в дерево путей и возвращает следующий узел для элемента-параметра указанного типа.
-// Возвращает ошибку, если в списке уже есть параметр с указанным типом, но с другим именем.
-func (pn *pathPartNode) addParam (name string, typ int) (*pathPartNode, error) {
+// Возвращает ошибку, если в списке уже есть параметр с указанным типом, но с другим именем,
+// либо с тем же именем, но с другим матчером (см. matchersEqual()).
+func (pn *pathPartNode) addParam (name string, typ int, matcher paramMatcher) (*pathPartNode, error) {
 	if pn.paramNode == nil {
 		result := &pathPartNode {}
-		pn.paramNode = &pathParamNode {Name: name, Type: typ, NextPart: result}
+		pn.paramNode = &pathParamNode {Name: name, Type: typ, NextPart: result, Matcher: matcher}
 		return result, nil
 	}
 
@@ -217,11 +224,15 @@ func (pn *pathPartNode) addParam (name string, typ int) (*pathPartNode, error) {
 	currentNode := pn.paramNode
 	for currentNode != nil && currentNode.Type <= typ {
 		if currentNode.Type == typ {
-			if currentNode.Name == name {
-				return currentNode.NextPart, nil
+			if currentNode.Name != name {
+				return nil, &RouteSpecError {"cannot add \"" + name + "\" parameter: \"" + currentNode.Name + "\" is already used"}
+			}
+
+			if !matchersEqual(currentNode.Matcher, matcher) {
+				return nil, &RouteSpecError {"cannot add \"" + name + "\" parameter: conflicting matcher already registered for this parameter"}
 			}
 
-			return nil, &RouteSpecError {"cannot add \"" + name + "\" parameter: \"" + currentNode.Name + "\" is already used"}
+			return currentNode.NextPart, nil
 		}
 
 		parentNode = currentNode
@@ -229,7 +240,7 @@ func (pn *pathPartNode) addParam (name string, typ int) (*pathPartNode, error) {
 	}
 
 	result := &pathPartNode {}
-	paramNode := &pathParamNode {Name: name, Type: typ, NextPart: result, NextParam: currentNode}
+	paramNode := &pathParamNode {Name: name, Type: typ, NextPart: result, NextParam: currentNode, Matcher: matcher}
 	if parentNode != nil {
 		parentNode.NextParam = paramNode
 	} else {
@@ -266,32 +277,17 @@ func (pn *pathPartNode) Match (value string) (node *pathPartNode, paramName stri
 }
 
 // matchParam() следующий узел дерева путей и имя параметра для заданного элемента URL-пути.
-// Возвращает nil и пустую строку, если соответствие не найдено.
+// Перебирает параметры по приоритету (см. addParam()) и использует первый,
+// чей Matcher сопоставился со значением. Возвращает nil и пустую строку,
+// если соответствие не найдено.
 func (pn *pathPartNode) matchParam (value string) (node *pathPartNode, paramName string) {
-	paramNode := pn.paramNode
-
-loop:
-	for paramNode != nil {
-		switch paramNode.Type {
-			case paramIndexType:
-				i, e := strconv.Atoi(value)
-				if e == nil && i > 0 {
-					break loop
-				}
-
-			default:
-				break loop
+	for paramNode := pn.paramNode; paramNode != nil; paramNode = paramNode.NextParam {
+		if _, ok := paramNode.Matcher.Match(value); ok {
+			return paramNode.NextPart, paramNode.Name
 		}
-
-		paramNode = paramNode.NextParam
 	}
 
-	if paramNode != nil {
-		return paramNode.NextPart, paramNode.Name
-
-	} else {
-		return nil, ""
-	}
+	return nil, ""
 }
 
 // GetHandler() возвращает обработчик для данного узла, если узел может быть
@@ -404,6 +400,35 @@ type PathRouter struct {
 	// pathTree - дерево заданных путей, изначально nil.
 	// Корень соответствует корневому URL-пути ("/").
 	pathTree *pathPartNode
+
+	// CleanPath - если true, то перед поиском путь запроса приводится к
+	// каноническому виду (см. CleanPath()) и обработчик ищется уже по нему -
+	// молча, без редиректа и без Location, даже если канонический вид
+	// отличается от исходного пути запроса. Если нужен именно редирект на
+	// канонический путь - используйте RedirectFixedPath. По умолчанию выключено.
+	CleanPath bool
+
+	// RedirectFixedPath - если true, то при неудачном поиске путь запроса
+	// приводится к каноническому виду (см. CleanPath()), и если он отличается
+	// от исходного и для него найден обработчик, то отправляется редирект на
+	// канонический путь (301 для GET/HEAD, 308 для остальных методов) с
+	// сохранением строки запроса. В отличие от CleanPath, обработчик при этом
+	// не вызывается. По умолчанию выключено.
+	//
+	// Отдельного RedirectTrailingSlash здесь нет и быть не может: splitPath()
+	// обрезает начальный/конечный "/" еще до попытки
+	// сопоставления, так что с точки зрения поиска пути наличие/отсутствие
+	// завершающего "/" не отличимо и само по себе никогда не дает "промаха",
+	// который стоило бы чинить редиректом - см. комментарий к PathRouter выше.
+	RedirectFixedPath bool
+
+	// paramsPool - пул срезов Params для ServeHTTP(), см. growParamsPool().
+	paramsPool *paramsPool
+
+	// maxParamDepth - число элементов самого длинного из зарегистрированных
+	// на данный момент путей (без учета "хвоста"), по которому подобрана
+	// емкость срезов в paramsPool.
+	maxParamDepth int
 }
 
 // NewPathRouter() создает маршрутизатор по URL-пути.
@@ -412,42 +437,83 @@ func NewPathRouter (defaultHandler http.Handler) *PathRouter {
 	if defaultHandler == nil {
 		defaultHandler = http.NotFoundHandler()
 	}
-	return &PathRouter {defaultHandler, &pathPartNode {}}
+	return &PathRouter {defaultHandler: defaultHandler, pathTree: &pathPartNode {}, paramsPool: newParamsPool(0)}
 }
 
-// Add() добавляет обработчик для указанного пути.
-// Возвращает ошибку, если путь задан некорректно либо обработчик для пути уже задан.
-func (pr *PathRouter) Add (pathString string, handler http.Handler) error {
-	var (e error; tailAllowed bool)
+// growParamsPool() расширяет (при необходимости) пул Params так, чтобы его
+// срезы вмещали параметры самого глубокого из зарегистрированных путей плюс
+// один слот на "хвост". Вызывается при регистрации маршрута, т. е. не на
+// горячем пути обработки запросов.
+func (pr *PathRouter) growParamsPool (depth int) {
+	if depth <= pr.maxParamDepth {
+		return
+	}
 
+	pr.maxParamDepth = depth
+	pr.paramsPool = newParamsPool(depth + 1)
+}
+
+// parsePathSpec() разбивает строку пути на элементы, определяя, допустим ли
+// завершающий "хвост" (элемент "*" либо эквивалентный ему "{name:*}").
+// Начальный и конечный "/" игнорируются.
+// Используется и PathRouter.Add(), и Router.Add().
+func parsePathSpec (pathString string) (parts []string, tailAllowed bool) {
 	pathString = strings.Trim(pathString, "/")
-	path := strings.Split(pathString, "/")
-	lastPart := path[len(path) - 1]
+	parts = strings.Split(pathString, "/")
+
+	lastPart := parts[len(parts) - 1]
 	if lastPart != "" {
-		tailAllowed = (lastPart[0] == pathOptTail)
+		tailAllowed = isTailSpec(lastPart)
 		if tailAllowed {
-			path = path[:len(path) - 1]
+			parts = parts[:len(parts) - 1]
 		}
 	}
 
-	if (len(path) == 1 && path[0] == "") || len(path) == 0 {
-		pr.pathTree.SetHandler(handler, tailAllowed)
-		return nil
+	if (len(parts) == 1 && parts[0] == "") || len(parts) == 0 {
+		parts = parts[:0]
 	}
 
-	node := pr.pathTree
-	for _, name := range path {
-		node, e = node.AddPart(name)
+	return parts, tailAllowed
+}
+
+// addPath() разбирает строку пути и строит/обходит дерево путей, начиная с
+// данного узла, возвращая конечный узел и признак допустимости "хвоста".
+// Попутно накапливает шаблон пути (pn.template, если задан, плюс разобранные
+// части pathString) и сохраняет его на конечном узле - см. pathPartNode.template.
+// Возвращает ошибку, если путь задан некорректно.
+func (pn *pathPartNode) addPath (pathString string) (node *pathPartNode, tailAllowed bool, e error) {
+	parts, tailAllowed := parsePathSpec(pathString)
+
+	template := append([]pathPartDescriptor {}, pn.template...)
+	node = pn
+	for _, part := range parts {
+		d, e := parsePathPart(part)
 		if e != nil {
-			return e
+			return nil, false, e
 		}
+
+		node, e = node.AddPart(part)
+		if e != nil {
+			return nil, false, e
+		}
+
+		template = append(template, d)
 	}
 
-	return node.SetHandler(handler, tailAllowed)
+	node.template = template
+	return node, tailAllowed, nil
 }
 
-type paramEntry struct {
-	name, value string
+// Add() добавляет обработчик для указанного пути.
+// Возвращает ошибку, если путь задан некорректно либо обработчик для пути уже задан.
+func (pr *PathRouter) Add (pathString string, handler http.Handler) error {
+	node, tailAllowed, e := pr.pathTree.addPath(pathString)
+	if e != nil {
+		return e
+	}
+
+	pr.growParamsPool(len(node.template))
+	return node.SetHandler(handler, tailAllowed)
 }
 
 // ServeHTTP() ищет и вызывает обработчик для текущего пути (либо обработчик по умолчанию).
@@ -457,21 +523,27 @@ func (pr *PathRouter) ServeHTTP (w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if path[0] == "" {
-		path = []string {}
+	urlPath := r.URL.Path
+	lookupPath := urlPath
+	if pr.CleanPath {
+		lookupPath = CleanPath(urlPath)
 	}
-	params := []paramEntry {}
+
+	path := splitPath(lookupPath)
+	paramsPtr := pr.paramsPool.get()
+	params := *paramsPtr
 	lastPartIndex := len(path) - 1
 
 	matchedHandler := pr.defaultHandler
 	matchedParamCnt := 0
 	matchedPos := 0
+	matched := false
 
 	node := pr.pathTree
 	handler := node.GetHandler(lastPartIndex >= 0)
 	if handler != nil {
 		matchedHandler = handler
+		matched = true
 	}
 
 	for i, part := range path {
@@ -482,7 +554,7 @@ func (pr *PathRouter) ServeHTTP (w http.ResponseWriter, r *http.Request) {
 		}
 
 		if paramName != "" {
-			params = append(params, paramEntry {paramName, part})
+			params = append(params, struct{ Key, Value string } {paramName, part})
 		}
 
 		node = nextNode
@@ -494,22 +566,33 @@ func (pr *PathRouter) ServeHTTP (w http.ResponseWriter, r *http.Request) {
 		matchedPos = i
 		matchedHandler = handler
 		matchedParamCnt = len(params)
+		matched = true
 	}
 
-	ctx := r.Context()
-	if matchedParamCnt > 0 {
-		for _, entry := range params[:matchedParamCnt] {
-			ctx = context.WithValue(ctx, entry.name, entry.value)
+	if !matched {
+		if location, ok := pr.redirectTarget(urlPath); ok {
+			*paramsPtr = params[:0]
+			pr.paramsPool.put(paramsPtr)
+
+			if r.URL.RawQuery != "" {
+				location += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, location, redirectStatusCode(r.Method))
+			return
 		}
 	}
 
+	params = params[:matchedParamCnt]
 	if matchedPos < lastPartIndex {
-		ctx = context.WithValue(ctx, "*", strings.Join(path[matchedPos + 1:], "/"))
+		params = append(params, struct{ Key, Value string } {"*", strings.Join(path[matchedPos + 1:], "/")})
 	}
 
-	if matchedParamCnt > 0 || matchedPos < lastPartIndex {
-		r = r.WithContext(ctx)
+	if len(params) > 0 {
+		r = r.WithContext(&paramsContext {Context: r.Context(), params: params})
 	}
 
 	matchedHandler.ServeHTTP(w, r)
+
+	*paramsPtr = params[:0]
+	pr.paramsPool.put(paramsPtr)
 }
@@ -1,91 +1,62 @@
 package router
 
 import (
-	"testing"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
-	"net/url"
+	"testing"
 )
 
+func TestMethodRouter (t *testing.T) {
+	var called string
 
-type mockHandler struct {
-	t *testing.T
-	Id string
-}
-
-var expectedHandler *mockHandler
-
-func (mh *mockHandler) ServeEx (c *Context) {
-	if expectedHandler.Id != mh.Id {
-		mh.t.Errorf("%s %s: expecting \"%s\" handler, got \"%s\"", c.Request.Method, c.Request.URL.String(), expectedHandler.Id, mh.Id)
+	h := func (name string) http.Handler {
+		return http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+			called = name
+		})
 	}
-}
-
 
-type mockWriter bool
+	r := NewMethodRouter(h("default"))
+	r.AddGet(h("get"))
+	r.AddPost(h("post"))
 
-func (*mockWriter) Header () http.Header {
-	return http.Header {}
-}
-
-func (*mockWriter) Write(p []byte) (int, error) {
-	return len(p), nil
-}
-
-func (*mockWriter) WriteHeader(statusCode int) {}
+	cases := []struct{method, expected string} {
+		{http.MethodDelete, "default"},
+		{http.MethodPost, "post"},
+		{http.MethodHead, "get"},
+		{http.MethodGet, "get"},
+		{http.MethodDelete, "default"},
+	}
 
+	for _, c := range cases {
+		called = ""
+		req := httptest.NewRequest(c.method, "http://example.com", nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
 
-func TestMethodRouter (t *testing.T) {
-	var (
-		mw mockWriter
-		mr http.Request
-	)
-
-	url, _ := url.Parse("example.com")
-	mr.URL = url
-
-	hGet := &mockHandler {t, "get"}
-	hPost := &mockHandler {t, "post"}
-	hHead := &mockHandler {t, "head"}
-	hDefault := &mockHandler {t, "default"}
-
-	r := NewMethodRouter(hDefault)
-	r.AddGet(hGet)
-	r.AddPost(hPost)
-
-	cases := []struct{m string; h *mockHandler} {
-		{http.MethodDelete, hDefault},
-		{http.MethodPost, hPost},
-		{http.MethodHead, hGet},
-		{http.MethodGet, hGet},
-		{http.MethodDelete, hDefault},
+		if called != c.expected {
+			t.Errorf("%s: expected %q handler, got %q", c.method, c.expected, called)
+		}
 	}
 
-	env := make(map[string]string)
+	r.Add(http.MethodHead, h("head"))
+	called = ""
+	req := httptest.NewRequest(http.MethodHead, "http://example.com", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
 
-	for _, c := range cases {
-		mr.Method = c.m
-		expectedHandler = c.h
-		r.ServeEx(&Context {&mw, &mr, env})
+	if called != "head" {
+		t.Errorf("HEAD: expected \"head\" handler, got %q", called)
 	}
-
-	r.Add(http.MethodHead, hHead)
-	mr.Method = http.MethodHead
-	expectedHandler = hHead
-	r.ServeEx(&Context {&mw, &mr, env})
 }
 
-
 func runPathSpecs (t *testing.T, cases [][]string, areValid bool) {
-	h := &mockHandler{t, ""}
 	mustFail := !areValid
 
 	for index, paths := range cases {
-		pr := NewPathRouter(h)
+		pr := NewPathRouter(http.NotFoundHandler())
 		lastPathIndex := len(paths) - 1
 
 		for i, path := range paths {
-			e := pr.Add(path, h)
+			e := pr.Add(path, http.NotFoundHandler())
 			if (e != nil) == (mustFail && i == lastPathIndex) {
 				continue
 			}
@@ -138,26 +109,20 @@ func TestInvalidPathSpecs (t *testing.T) {
 	runPathSpecs(t, cases, false)
 }
 
-type (
-	pathSpec struct {
-		Path string
-		Id string
+func TestPathRouter (t *testing.T) {
+	var gotId string
+	var gotParams Params
+
+	namedHandler := func (id string) http.Handler {
+		return http.HandlerFunc(func (w http.ResponseWriter, req *http.Request) {
+			gotId = id
+			gotParams = ParamsFromContext(req.Context())
+		})
 	}
 
-	mp map[string]string
+	pr := NewPathRouter(namedHandler("default"))
 
-	pathMatch struct {
-		Url string
-		Expected string
-		Params mp
-	}
-)
-
-func TestPathRouter (t *testing.T) {
-	pr := NewPathRouter(&mockHandler{t, "default"})
-	v := make(mp, 0)
-
-	specs := []pathSpec {
+	specs := []struct{ Path, Id string } {
 		{"", "root"},
 		{"files/*", "file"},
 		{"files/config.js", "config"},
@@ -170,58 +135,49 @@ func TestPathRouter (t *testing.T) {
 		{"foo/$bar/baz", "foobar"},
 	}
 
-	matches := []pathMatch {
-		{"/invalid/path", "default", v},
-		{"/", "root", v},
-		{"/files", "file", v},
-		{"/files/index.html", "file", mp {"*": "index.html"}},
-		{"/files/css/style.css", "file", mp {"*": "css/style.css"}},
-		{"/files/Foo.txt?t=123", "file", mp {"*": "Foo.txt"}},
-		{"/files/config.js", "config", v},
-		{"/user", "default", v},
-		{"/user/123", "profile", mp {"uid": "123"}},
-		{"/User/123", "default", v},
-		{"/user/12/avatar", "avatar", mp {"uid": "12"}},
-		{"/user/12/avatar/orig", "orig", mp {"uid": "12"}},
-		{"/user/0", "action", mp {"action": "0"}},
-		{"/user/1a", "action", mp {"action": "1a"}},
-		{"/user/list", "action", mp {"action": "list"}},
-		{"/user/list/all", "action", mp {"action": "list", "*": "all"}},
-		{"/user/123/message", "id-action", mp {"uid": "123", "action": "message"}},
-		{"/foo/123/zab", "foo", mp {"bar": ""}},
-	}
-
-	var mw mockWriter
-	expectedHandler = &mockHandler {}
 	for _, spec := range specs {
-		e := pr.Add(spec.Path, &mockHandler {t, spec.Id})
-		if e != nil {
-			t.Error(e.Error())
-			return
+		if e := pr.Add(spec.Path, namedHandler(spec.Id)); e != nil {
+			t.Fatal(e.Error())
 		}
 	}
 
+	matches := []struct {
+		Url, Expected string
+		Params        map[string]string
+	} {
+		{"/invalid/path", "default", nil},
+		{"/", "root", nil},
+		{"/files", "file", nil},
+		{"/files/index.html", "file", map[string]string {"*": "index.html"}},
+		{"/files/css/style.css", "file", map[string]string {"*": "css/style.css"}},
+		{"/files/Foo.txt?t=123", "file", map[string]string {"*": "Foo.txt"}},
+		{"/files/config.js", "config", nil},
+		{"/user", "default", nil},
+		{"/user/123", "profile", map[string]string {"uid": "123"}},
+		{"/User/123", "default", nil},
+		{"/user/12/avatar", "avatar", map[string]string {"uid": "12"}},
+		{"/user/12/avatar/orig", "orig", map[string]string {"uid": "12"}},
+		{"/user/0", "action", map[string]string {"action": "0"}},
+		{"/user/1a", "action", map[string]string {"action": "1a"}},
+		{"/user/list", "action", map[string]string {"action": "list"}},
+		{"/user/list/all", "action", map[string]string {"action": "list", "*": "all"}},
+		{"/user/123/message", "id-action", map[string]string {"uid": "123", "action": "message"}},
+		{"/foo/123/zab", "foo", map[string]string {"bar": ""}},
+	}
+
 	for _, match := range matches {
-		request := http.Request {}
-		Url, e := url.Parse(match.Url)
-		if e != nil {
-			t.Error(e.Error())
+		req := httptest.NewRequest(http.MethodGet, match.Url, nil)
+		pr.ServeHTTP(httptest.NewRecorder(), req)
+
+		if gotId != match.Expected {
+			t.Errorf("%s: expected %q handler, got %q", match.Url, match.Expected, gotId)
 			continue
 		}
 
-		request.URL = Url
-		expectedHandler.Id = match.Expected
-		c := &Context {&mw, &request, make(map[string]string)}
-		pr.ServeEx(c)
-
-		if match.Params != nil && len(match.Params) > 0 {
-			for name, value := range match.Params {
-				got := c.Env[name]
-				if got != value {
-					t.Errorf("%s, expected %s=%s, got %v", match.Url, name, value, got)
-				}
+		for name, value := range match.Params {
+			if got := gotParams.Get(name); got != value {
+				t.Errorf("%s: expected %s=%q, got %q", match.Url, name, value, got)
 			}
 		}
 	}
 }
-